@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"math"
 	"strconv"
 	"strings"
 	"time"
@@ -103,8 +104,20 @@ func (p *JSONParser) SetDefaultTags(tags map[string]string) {
 	p.DefaultTags = tags
 }
 
+// maxExactFloat is the largest integer a float64 represents exactly
+const maxExactFloat = 1 << 53
+
+// JSONFlattener flattens decoded JSON into a fields map. Numbers decoded
+// as json.Number are stored as float64 unless their magnitude exceeds
+// 2^53, in which case they are stored as int64 (or uint64 above
+// math.MaxInt64). A counter crossing 2^53 therefore changes type once.
+// The metric package still caps uint64 values at math.MaxInt64 when
+// writing line protocol.
 type JSONFlattener struct {
 	Fields map[string]interface{}
+
+	// Separator joins nested keys into a field name, defaults to "_"
+	Separator string
 }
 
 // FlattenJSON flattens nested maps/interfaces into a fields map (ignoring bools and string)
@@ -127,11 +140,20 @@ func (f *JSONFlattener) FullFlattenJSON(
 	if f.Fields == nil {
 		f.Fields = make(map[string]interface{})
 	}
-	fieldname = strings.Trim(fieldname, "_")
+	sep := f.Separator
+	if sep == "" {
+		sep = "_"
+	}
+	if sep == "_" {
+		// Keep the historic trimming so existing field names do not change
+		fieldname = strings.Trim(fieldname, sep)
+	} else {
+		fieldname = strings.TrimSuffix(strings.TrimPrefix(fieldname, sep), sep)
+	}
 	switch t := v.(type) {
 	case map[string]interface{}:
 		for k, v := range t {
-			err := f.FullFlattenJSON(fieldname+"_"+k+"_", v, convertString, convertBool)
+			err := f.FullFlattenJSON(fieldname+sep+k+sep, v, convertString, convertBool)
 			if err != nil {
 				return err
 			}
@@ -139,13 +161,27 @@ func (f *JSONFlattener) FullFlattenJSON(
 	case []interface{}:
 		for i, v := range t {
 			k := strconv.Itoa(i)
-			err := f.FullFlattenJSON(fieldname+"_"+k+"_", v, convertString, convertBool)
+			err := f.FullFlattenJSON(fieldname+sep+k+sep, v, convertString, convertBool)
 			if err != nil {
 				return nil
 			}
 		}
 	case float64:
 		f.Fields[fieldname] = t
+	case json.Number:
+		// Produced when decoding with UseNumber. Integers beyond what a
+		// float64 holds exactly become int64, or uint64 above MaxInt64;
+		// everything else stays float64 so a field keeps a single type.
+		if i, err := t.Int64(); err == nil && (i > maxExactFloat || i < -maxExactFloat) {
+			f.Fields[fieldname] = i
+		} else if u, err := strconv.ParseUint(t.String(), 10, 64); err == nil && u > math.MaxInt64 {
+			f.Fields[fieldname] = u
+		} else if fl, err := t.Float64(); err == nil {
+			f.Fields[fieldname] = fl
+		} else {
+			return fmt.Errorf("JSON Flattener: unable to convert number %s (%s)",
+				t, fieldname)
+		}
 	case string:
 		if convertString {
 			f.Fields[fieldname] = v.(string)
@@ -1,6 +1,8 @@
 package json
 
 import (
+	"encoding/json"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -428,3 +430,106 @@ func TestParseArrayWithTagKeys(t *testing.T) {
 		"othertag": "baz",
 	}, metrics[1].Tags())
 }
+
+func TestFlattenJSONNumber(t *testing.T) {
+	// 2^53 + 1 cannot be represented exactly as a float64, and the
+	// uint64 maximum does not fit in an int64
+	const largeJSON = `
+{
+    "memstats": {
+        "memory_total": 9007199254740993,
+        "gc_next": 1.5
+    },
+    "events": 18446744073709551615,
+    "exact": 9007199254740992,
+    "load": 1,
+    "load5": 1.5
+}
+`
+
+	var jsonOut map[string]interface{}
+	decoder := json.NewDecoder(strings.NewReader(largeJSON))
+	decoder.UseNumber()
+	assert.NoError(t, decoder.Decode(&jsonOut))
+
+	f := JSONFlattener{}
+	err := f.FlattenJSON("", jsonOut)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"memstats_memory_total": int64(9007199254740993),
+		"memstats_gc_next":      float64(1.5),
+		"events":                uint64(18446744073709551615),
+		"exact":                 float64(9007199254740992),
+		"load":                  float64(1),
+		"load5":                 float64(1.5),
+	}, f.Fields)
+}
+
+func TestFlattenJSONSeparator(t *testing.T) {
+	var jsonOut map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(validJSON), &jsonOut))
+
+	f := JSONFlattener{Separator: "."}
+	err := f.FlattenJSON("", jsonOut)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"a":   float64(5),
+		"b.c": float64(6),
+	}, f.Fields)
+
+	// Array elements are joined with the separator as well
+	jsonOut = nil
+	assert.NoError(t, json.Unmarshal([]byte(`{"a": 5, "b": [6, 7]}`), &jsonOut))
+
+	f = JSONFlattener{Separator: "."}
+	err = f.FlattenJSON("", jsonOut)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"a":   float64(5),
+		"b.0": float64(6),
+		"b.1": float64(7),
+	}, f.Fields)
+
+	// An explicit underscore matches the default
+	f = JSONFlattener{Separator: "_"}
+	err = f.FlattenJSON("", jsonOut)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"a":   float64(5),
+		"b_0": float64(6),
+		"b_1": float64(7),
+	}, f.Fields)
+
+	// Only the separator itself is trimmed, not its individual characters
+	jsonOut = nil
+	assert.NoError(t, json.Unmarshal([]byte(`{"rate-": {"x": 1}, "rate": {"x": 2}}`), &jsonOut))
+
+	f = JSONFlattener{Separator: "->"}
+	err = f.FlattenJSON("", jsonOut)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"rate-->x": float64(1),
+		"rate->x":  float64(2),
+	}, f.Fields)
+}
+
+func TestFlattenJSONDefaultSeparator(t *testing.T) {
+	// Leading and trailing underscores in keys are stripped by default
+	var jsonOut map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(`{"_all": {"count_": 1}}`), &jsonOut))
+
+	f := JSONFlattener{}
+	err := f.FlattenJSON("", jsonOut)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"all_count": float64(1),
+	}, f.Fields)
+
+	// Setting the default explicitly must not change the field names
+	f = JSONFlattener{Separator: "_"}
+	err = f.FlattenJSON("", jsonOut)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"all_count": float64(1),
+	}, f.Fields)
+}